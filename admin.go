@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/jpjerkins/simple-web-host/metrics"
+)
+
+// metricsAddr returns the bind address for internal-only operational
+// endpoints. It defaults to loopback so it is never reachable through
+// the public listener, preserving the "no debug endpoints on the public
+// surface" invariant.
+func metricsAddr() string {
+	return getEnv("METRICS_ADDR", "127.0.0.1:9090")
+}
+
+// startAdminListener serves endpoints that expose internal state (queue
+// depth, dropped log entries, Prometheus metrics, ...) without putting
+// them on the public mux.
+func (s *Server) startAdminListener() {
+	metrics.RegisterGauge("log_queue_depth", func() float64 {
+		return float64(s.totalQueuedLogEntries())
+	})
+	metrics.RegisterGauge("open_file_descriptors", func() float64 {
+		return float64(openFDCount())
+	})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/log-stats", s.handleLogStats)
+	mux.HandleFunc("/metrics", handleMetrics)
+
+	addr := metricsAddr()
+	srv := &http.Server{Addr: addr, Handler: mux}
+	s.adminServer = srv
+
+	log.Printf("Starting internal admin listener on %s", addr)
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Printf("Admin listener stopped: %v", err)
+		}
+	}()
+}
+
+func handleMetrics(w http.ResponseWriter, r *http.Request) {
+	metrics.WriteText(w)
+}
+
+func (s *Server) handleLogStats(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintf(w, "log_entries_dropped_total %d\n", s.totalDroppedLogEntries())
+	fmt.Fprintf(w, "log_queue_depth %d\n", s.totalQueuedLogEntries())
+}
+
+func (s *Server) totalDroppedLogEntries() int64 {
+	s.sitesMu.RLock()
+	defer s.sitesMu.RUnlock()
+
+	var total int64
+	for _, site := range s.sites {
+		total += site.logWriter.droppedCount()
+	}
+	return total
+}
+
+func (s *Server) totalQueuedLogEntries() int64 {
+	s.sitesMu.RLock()
+	defer s.sitesMu.RUnlock()
+
+	var total int64
+	for _, site := range s.sites {
+		total += site.logWriter.queuedCount()
+	}
+	return total
+}
+
+// openFDCount reports the current process's open file descriptor count
+// on Linux, where /proc is available. It returns 0 elsewhere rather
+// than failing the scrape.
+func openFDCount() int {
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return 0
+	}
+	return len(entries)
+}
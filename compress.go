@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// compressibleExts lists the static asset extensions worth compressing,
+// either on the fly or by serving a precompressed sibling.
+var compressibleExts = map[string]bool{
+	".html": true,
+	".css":  true,
+	".js":   true,
+	".svg":  true,
+	".json": true,
+	".txt":  true,
+	".md":   true,
+}
+
+const defaultMinCompressSize = 1024
+
+func minCompressSize() int64 {
+	if v := os.Getenv("COMPRESS_MIN_SIZE"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n >= 0 {
+			return n
+		}
+	}
+	return defaultMinCompressSize
+}
+
+func gzipLevel() int {
+	if v := os.Getenv("COMPRESS_LEVEL"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= gzip.HuffmanOnly && n <= gzip.BestCompression {
+			return n
+		}
+	}
+	return gzip.DefaultCompression
+}
+
+// compressible reports whether path's extension is one we compress.
+func compressible(path string) bool {
+	return compressibleExts[strings.ToLower(filepath.Ext(path))]
+}
+
+// acceptsEncoding reports whether the client's Accept-Encoding header
+// lists the given encoding. Quality values are ignored since we only
+// ever offer a single encoding per response.
+func acceptsEncoding(r *http.Request, encoding string) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		if strings.EqualFold(name, encoding) {
+			return true
+		}
+	}
+	return false
+}
+
+// precompressed looks for a sibling .br or .gz file next to path and
+// returns it if the client advertises support for that encoding. Brotli
+// is preferred over gzip when both exist and are accepted.
+func precompressed(r *http.Request, path string) (variantPath, encoding string, ok bool) {
+	if acceptsEncoding(r, "br") {
+		if info, err := os.Stat(path + ".br"); err == nil && !info.IsDir() {
+			return path + ".br", "br", true
+		}
+	}
+	if acceptsEncoding(r, "gzip") {
+		if info, err := os.Stat(path + ".gz"); err == nil && !info.IsDir() {
+			return path + ".gz", "gzip", true
+		}
+	}
+	return "", "", false
+}
+
+// gzipResponseWriter transparently gzip-encodes the response body
+// written through it. It wraps a *responseRecorder so the log entry's
+// bytesWritten still reflects the compressed bytes actually put on the
+// wire, and implements http.Flusher/http.Hijacker so it composes with
+// whatever the underlying ResponseWriter supports.
+type gzipResponseWriter struct {
+	*responseRecorder
+	gz *gzip.Writer
+}
+
+func newGzipResponseWriter(rr *responseRecorder, level int) *gzipResponseWriter {
+	gz, _ := gzip.NewWriterLevel(rr, level) // level is pre-validated by gzipLevel
+	return &gzipResponseWriter{responseRecorder: rr, gz: gz}
+}
+
+func (g *gzipResponseWriter) WriteHeader(code int) {
+	g.Header().Del("Content-Length") // length changes once compressed
+	g.Header().Set("Content-Encoding", "gzip")
+	g.Header().Add("Vary", "Accept-Encoding")
+	g.responseRecorder.WriteHeader(code)
+}
+
+func (g *gzipResponseWriter) Write(p []byte) (int, error) {
+	if !g.wroteHeader {
+		g.WriteHeader(http.StatusOK)
+	}
+	return g.gz.Write(p)
+}
+
+// Close flushes and closes the gzip stream. Callers must invoke this
+// after the handler finishes writing the response.
+func (g *gzipResponseWriter) Close() error {
+	return g.gz.Close()
+}
+
+func (g *gzipResponseWriter) Flush() {
+	g.gz.Flush()
+	if f, ok := g.responseRecorder.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (g *gzipResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := g.responseRecorder.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("underlying ResponseWriter does not support hijacking")
+	}
+	return hj.Hijack()
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCompressible(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{path: "/style.css", want: true},
+		{path: "/app.JS", want: true},
+		{path: "/photo.png", want: false},
+		{path: "/noext", want: false},
+	}
+
+	for _, tc := range tests {
+		if got := compressible(tc.path); got != tc.want {
+			t.Errorf("compressible(%q) = %v, want %v", tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestAcceptsEncoding(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		encoding string
+		want     bool
+	}{
+		{name: "exact match", header: "gzip", encoding: "gzip", want: true},
+		{name: "list match", header: "deflate, gzip, br", encoding: "gzip", want: true},
+		{name: "quality value ignored", header: "gzip;q=0.8", encoding: "gzip", want: true},
+		{name: "case-insensitive", header: "GZIP", encoding: "gzip", want: true},
+		{name: "not present", header: "deflate, br", encoding: "gzip", want: false},
+		{name: "empty header", header: "", encoding: "gzip", want: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.header != "" {
+				r.Header.Set("Accept-Encoding", tc.header)
+			}
+			if got := acceptsEncoding(r, tc.encoding); got != tc.want {
+				t.Errorf("acceptsEncoding() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
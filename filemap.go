@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fileMapRefreshInterval controls how often the www root is rescanned to
+// pick up added, removed, or changed files without a server restart.
+const fileMapRefreshInterval = 30 * time.Second
+
+// fileEntry describes a single servable file discovered under wwwRoot.
+type fileEntry struct {
+	path  string // absolute path on disk
+	size  int64
+	mtime time.Time
+	etag  string
+}
+
+// fileMap holds the canonical set of servable files, keyed by URL path
+// (e.g. "/index.html"). Only files that pass the extension whitelist are
+// present, so a successful lookup also means the request is allowed.
+type fileMap struct {
+	mu      sync.RWMutex
+	entries map[string]fileEntry
+}
+
+func newFileMap() *fileMap {
+	return &fileMap{entries: make(map[string]fileEntry)}
+}
+
+func (fm *fileMap) lookup(urlPath string) (fileEntry, bool) {
+	fm.mu.RLock()
+	defer fm.mu.RUnlock()
+	e, ok := fm.entries[urlPath]
+	return e, ok
+}
+
+func (fm *fileMap) set(entries map[string]fileEntry) {
+	fm.mu.Lock()
+	fm.entries = entries
+	fm.mu.Unlock()
+}
+
+// scan walks the site's root (flat namespace only, matching
+// sanitizePath) and builds a fresh fileEntry for every file whose
+// extension passes the site's whitelist.
+func (site *Site) scan() (map[string]fileEntry, error) {
+	dirEntries, err := os.ReadDir(site.root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read site root %s: %w", site.root, err)
+	}
+
+	entries := make(map[string]fileEntry, len(dirEntries))
+	for _, de := range dirEntries {
+		if de.IsDir() {
+			continue
+		}
+
+		name := de.Name()
+		ext := strings.ToLower(filepath.Ext(name))
+		if site.blockedExts[ext] || !site.allowedExts[ext] {
+			continue
+		}
+
+		info, err := de.Info()
+		if err != nil {
+			log.Printf("Failed to stat %s during file map scan: %v", name, err)
+			continue
+		}
+
+		fullPath := filepath.Join(site.root, name)
+		etag, err := computeETag(fullPath)
+		if err != nil {
+			log.Printf("Failed to compute ETag for %s: %v", name, err)
+			continue
+		}
+
+		entries["/"+name] = fileEntry{
+			path:  fullPath,
+			size:  info.Size(),
+			mtime: info.ModTime(),
+			etag:  etag,
+		}
+	}
+
+	return entries, nil
+}
+
+// computeETag returns a weak validator derived from an FNV-64a hash of
+// the file contents. FNV is not cryptographically strong, but it is fast
+// and more than sufficient for cache validation.
+func computeETag(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := fnv.New64a()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return `W/"` + strconv.FormatUint(h.Sum64(), 16) + `"`, nil
+}
+
+// refresh rebuilds the site's file map in place. Scan errors are logged
+// but not fatal, so a transient filesystem hiccup doesn't take down the
+// server or blow away the last-known-good map.
+func (site *Site) refresh() {
+	entries, err := site.scan()
+	if err != nil {
+		log.Printf("Failed to refresh file map for site %q: %v", site.host, err)
+		return
+	}
+	site.fileMap.set(entries)
+}
+
+func (site *Site) periodicRefresh() {
+	ticker := time.NewTicker(fileMapRefreshInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		site.refresh()
+	}
+}
+
+// notModified reports whether the request's conditional headers indicate
+// the client's cached copy is still fresh. If-None-Match takes precedence
+// over If-Modified-Since, per RFC 7232.
+func notModified(r *http.Request, entry fileEntry) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return inm == entry.etag
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" {
+		t, err := time.Parse(http.TimeFormat, ims)
+		if err == nil && !entry.mtime.Truncate(time.Second).After(t) {
+			return true
+		}
+	}
+	return false
+}
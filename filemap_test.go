@@ -0,0 +1,45 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNotModified(t *testing.T) {
+	mtime := time.Date(2026, 7, 29, 12, 0, 0, 0, time.UTC)
+	entry := fileEntry{etag: `W/"abc123"`, mtime: mtime}
+
+	tests := []struct {
+		name  string
+		inm   string
+		ims   string
+		entry fileEntry
+		want  bool
+	}{
+		{name: "no conditional headers", entry: entry, want: false},
+		{name: "matching etag", inm: `W/"abc123"`, entry: entry, want: true},
+		{name: "mismatched etag", inm: `W/"different"`, entry: entry, want: false},
+		{name: "etag takes precedence over stale date", inm: `W/"different"`, ims: mtime.Add(time.Hour).Format(http.TimeFormat), entry: entry, want: false},
+		{name: "not modified since", ims: mtime.Format(http.TimeFormat), entry: entry, want: true},
+		{name: "modified since", ims: mtime.Add(-time.Hour).Format(http.TimeFormat), entry: entry, want: false},
+		{name: "unparseable date", ims: "not-a-date", entry: entry, want: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/file.html", nil)
+			if tc.inm != "" {
+				r.Header.Set("If-None-Match", tc.inm)
+			}
+			if tc.ims != "" {
+				r.Header.Set("If-Modified-Since", tc.ims)
+			}
+
+			if got := notModified(r, tc.entry); got != tc.want {
+				t.Errorf("notModified() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,244 @@
+// Package gopher serves a flat directory of files over the Gopher
+// protocol (RFC 1436), mirroring the extension whitelist and flat
+// namespace rules the HTTP server enforces.
+package gopher
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// connReadTimeout bounds how long handleConn waits for a client to send
+// its CRLF-terminated selector line, closing off the same slowloris
+// exposure the HTTP listener avoids with ReadHeaderTimeout.
+const connReadTimeout = 10 * time.Second
+
+// LogFunc lets the caller fold gopher hits into its own log pipeline.
+// The arguments mirror the HTTP server's LogEntry fields.
+type LogFunc func(method, path string, status int, bytes int64, durationMS float64)
+
+// Server serves Root over the Gopher protocol. Only files whose
+// extension is in AllowedExts are listed or servable.
+type Server struct {
+	Root        string
+	AllowedExts map[string]bool
+	Hostname    string
+	Port        string
+	Log         LogFunc
+
+	mu      sync.Mutex
+	ln      net.Listener
+	closing bool
+	conns   sync.WaitGroup
+}
+
+// ListenAndServe accepts connections on addr until the listener fails or
+// Shutdown is called, in which case it returns nil.
+func (s *Server) ListenAndServe(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.ln = ln
+	s.mu.Unlock()
+	defer ln.Close()
+
+	var tempDelay time.Duration
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if s.isClosing() {
+				return nil
+			}
+			// Mirrors net/http's Server.Serve: back off and retry on a
+			// transient accept error (e.g. fd exhaustion) instead of
+			// busy-looping, but give up on anything else.
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				if tempDelay == 0 {
+					tempDelay = 5 * time.Millisecond
+				} else {
+					tempDelay *= 2
+				}
+				if max := 1 * time.Second; tempDelay > max {
+					tempDelay = max
+				}
+				log.Printf("gopher: accept error: %v; retrying in %v", err, tempDelay)
+				time.Sleep(tempDelay)
+				continue
+			}
+			return err
+		}
+		tempDelay = 0
+
+		s.conns.Add(1)
+		go func() {
+			defer s.conns.Done()
+			s.handleConn(conn)
+		}()
+	}
+}
+
+// Shutdown stops accepting new connections and blocks until every
+// in-flight connection's goroutine has returned or ctx is done,
+// whichever comes first - the same contract http.Server.Shutdown gives
+// the HTTP/HTTPS listeners.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.closing = true
+	ln := s.ln
+	s.mu.Unlock()
+
+	if ln != nil {
+		if err := ln.Close(); err != nil {
+			return err
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.conns.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Server) isClosing() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closing
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	defer conn.Close()
+	start := time.Now()
+
+	conn.SetReadDeadline(time.Now().Add(connReadTimeout))
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return
+	}
+	selector := strings.TrimRight(line, "\r\n")
+
+	status, n, err := s.serveSelector(conn, selector)
+	if err != nil {
+		log.Printf("gopher: error serving selector %q: %v", selector, err)
+	}
+
+	if s.Log != nil {
+		s.Log("GOPHER", "/"+selector, status, n, time.Since(start).Seconds()*1000)
+	}
+}
+
+// serveSelector handles a single CRLF-terminated selector line: the
+// empty selector gets a synthesized gophermap of the root, anything
+// else is looked up as a flat filename under Root.
+func (s *Server) serveSelector(w io.Writer, selector string) (status int, bytesWritten int64, err error) {
+	if selector == "" {
+		return s.serveMenu(w)
+	}
+
+	path, err := s.sanitize(selector)
+	if err != nil {
+		n, _ := io.WriteString(w, gopherError("not found"))
+		return 404, int64(n), nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		n, _ := io.WriteString(w, gopherError("not found"))
+		return 404, int64(n), nil
+	}
+	defer f.Close()
+
+	n, err := io.Copy(w, f)
+	return 200, n, err
+}
+
+// sanitize enforces the same flat-namespace and no-traversal rules as the
+// HTTP server's sanitizePath, plus the extension whitelist that the HTTP
+// server applies through its file map.
+func (s *Server) sanitize(selector string) (string, error) {
+	selector = strings.TrimPrefix(selector, "/")
+	if selector == "" || strings.ContainsAny(selector, "/\\") || strings.Contains(selector, "..") {
+		return "", fmt.Errorf("invalid selector %q", selector)
+	}
+
+	ext := strings.ToLower(filepath.Ext(selector))
+	if !s.AllowedExts[ext] {
+		return "", fmt.Errorf("extension not allowed: %s", ext)
+	}
+
+	return filepath.Join(s.Root, selector), nil
+}
+
+func (s *Server) serveMenu(w io.Writer) (status int, bytesWritten int64, err error) {
+	dirEntries, err := os.ReadDir(s.Root)
+	if err != nil {
+		return 500, 0, err
+	}
+
+	names := make([]string, 0, len(dirEntries))
+	for _, de := range dirEntries {
+		if de.IsDir() {
+			continue
+		}
+		if !s.AllowedExts[strings.ToLower(filepath.Ext(de.Name()))] {
+			continue
+		}
+		names = append(names, de.Name())
+	}
+	sort.Strings(names)
+
+	var written int64
+	for _, name := range names {
+		itemType := gopherItemType(strings.ToLower(filepath.Ext(name)))
+		line := fmt.Sprintf("%c%s\t%s\t%s\t%s\r\n", itemType, name, name, s.Hostname, s.Port)
+		n, err := io.WriteString(w, line)
+		written += int64(n)
+		if err != nil {
+			return 500, written, err
+		}
+	}
+
+	n, err := io.WriteString(w, ".\r\n")
+	written += int64(n)
+	return 200, written, err
+}
+
+// gopherItemType maps a file extension to its RFC 1436 item type.
+func gopherItemType(ext string) byte {
+	switch ext {
+	case ".gif":
+		return 'g'
+	case ".html":
+		return 'h'
+	case ".png", ".jpg", ".jpeg", ".webp", ".ico", ".svg":
+		return 'I'
+	case ".txt", ".md", ".json":
+		return '0'
+	default:
+		return '9'
+	}
+}
+
+func gopherError(msg string) string {
+	return fmt.Sprintf("3%s\terror\tnone\t0\r\n", msg)
+}
@@ -0,0 +1,81 @@
+package gopher
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestServerSanitize(t *testing.T) {
+	s := &Server{Root: "/var/gopher", AllowedExts: map[string]bool{".txt": true, ".html": true}}
+
+	tests := []struct {
+		name     string
+		selector string
+		wantErr  bool
+	}{
+		{name: "ordinary file", selector: "readme.txt", wantErr: false},
+		{name: "leading slash", selector: "/readme.txt", wantErr: false},
+		{name: "empty selector", selector: "", wantErr: true},
+		{name: "subdirectory", selector: "sub/readme.txt", wantErr: true},
+		{name: "backslash", selector: "sub\\readme.txt", wantErr: true},
+		{name: "traversal", selector: "../secret.txt", wantErr: true},
+		{name: "disallowed extension", selector: "readme.exe", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := s.sanitize(tc.selector)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("sanitize(%q) error = %v, wantErr %v", tc.selector, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+// TestServerShutdownStopsAccepting verifies that Shutdown stops
+// ListenAndServe's accept loop (returning nil rather than a panic or a
+// hung goroutine) instead of leaving it to keep accepting, which is
+// what let the process panic on a send to a closed log channel.
+func TestServerShutdownStopsAccepting(t *testing.T) {
+	s := &Server{Root: t.TempDir(), AllowedExts: map[string]bool{".txt": true}}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- s.ListenAndServe(addr)
+	}()
+
+	// Give ListenAndServe a moment to bind before shutting it down.
+	for i := 0; i < 100; i++ {
+		s.mu.Lock()
+		ready := s.ln != nil
+		s.mu.Unlock()
+		if ready {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	select {
+	case err := <-serveErr:
+		if err != nil {
+			t.Errorf("ListenAndServe() returned %v after Shutdown, want nil", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("ListenAndServe did not return after Shutdown")
+	}
+}
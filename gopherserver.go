@@ -0,0 +1,59 @@
+package main
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jpjerkins/simple-web-host/gopher"
+)
+
+// startGopherListener starts a Gopher (RFC 1436) listener alongside the
+// HTTP server when GOPHER_ADDR is set. It serves the default site's
+// root - gopher has no Host-based virtual hosting, so multi-site setups
+// only expose the default site over gopher.
+func (s *Server) startGopherListener() {
+	addr := getEnv("GOPHER_ADDR", "")
+	if addr == "" {
+		return
+	}
+
+	site := s.defaultSite()
+	if site == nil {
+		log.Printf("gopher: no default site registered, skipping gopher listener")
+		return
+	}
+
+	srv := &gopher.Server{
+		Root:        site.root,
+		AllowedExts: site.allowedExts,
+		Hostname:    getEnv("GOPHER_HOSTNAME", "localhost"),
+		Port:        gopherPort(addr),
+		Log: func(method, path string, status int, bytes int64, durationMS float64) {
+			site.logWriter.submit(LogEntry{
+				Timestamp: time.Now().In(s.location).Format(time.RFC3339),
+				Method:    method,
+				Path:      path,
+				Status:    status,
+				Bytes:     bytes,
+				Duration:  durationMS,
+			})
+		},
+	}
+
+	s.gopherServer = srv
+
+	log.Printf("Starting gopher listener on %s", addr)
+	go func() {
+		if err := srv.ListenAndServe(addr); err != nil {
+			log.Printf("Gopher listener stopped: %v", err)
+		}
+	}()
+}
+
+func gopherPort(addr string) string {
+	if i := strings.LastIndex(addr, ":"); i != -1 {
+		return addr[i+1:]
+	}
+	return "70"
+}
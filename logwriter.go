@@ -0,0 +1,202 @@
+package main
+
+import (
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	logChannelSize           = 4096
+	defaultLogFlushInterval  = 1 * time.Second
+	defaultLogFlushBatchSize = 200
+)
+
+// logFlushInterval returns how often the log writer flushes on a timer,
+// overridable via LOG_FLUSH_INTERVAL (milliseconds).
+func logFlushInterval() time.Duration {
+	if v := os.Getenv("LOG_FLUSH_INTERVAL"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return time.Duration(n) * time.Millisecond
+		}
+	}
+	return defaultLogFlushInterval
+}
+
+// logFlushBatchSize returns how many entries the log writer batches
+// before flushing early, overridable via LOG_FLUSH_BATCH_SIZE.
+func logFlushBatchSize() int {
+	if v := os.Getenv("LOG_FLUSH_BATCH_SIZE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return n
+		}
+	}
+	return defaultLogFlushBatchSize
+}
+
+// logWriter owns the single open log file handle for the current hour
+// bucket and batches fsyncs; requests hand entries over a channel, so the
+// request path never blocks on disk I/O or opens/closes a file per request.
+type logWriter struct {
+	logDir   string
+	location *time.Location
+
+	entries chan LogEntry
+	done    chan struct{}
+
+	file         *os.File
+	bucket       string // current hour bucket, e.g. "2026-07-29T14"
+	logger       *slog.Logger
+	pending      int
+	droppedTotal atomic.Int64
+	queueDepth   atomic.Int64
+
+	closeOnce sync.Once
+}
+
+func newLogWriter(logDir string, location *time.Location) *logWriter {
+	return &logWriter{
+		logDir:   logDir,
+		location: location,
+		entries:  make(chan LogEntry, logChannelSize),
+		done:     make(chan struct{}),
+	}
+}
+
+// submit enqueues entry for writing. If the channel is full the entry is
+// dropped rather than blocking the request path; drops are counted so
+// they're visible on the admin endpoint instead of silently vanishing.
+func (lw *logWriter) submit(entry LogEntry) {
+	select {
+	case lw.entries <- entry:
+		lw.queueDepth.Add(1)
+	default:
+		lw.droppedTotal.Add(1)
+		log.Printf("Log channel full, dropping entry")
+	}
+}
+
+// run drains the channel until it's closed, writing each entry and
+// fsyncing per batch or on a timer so entries don't sit unsynced indefinitely. It
+// must be started as a goroutine and is the only goroutine that touches
+// lw.file, so no locking is needed around it.
+func (lw *logWriter) run() {
+	ticker := time.NewTicker(logFlushInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case entry, ok := <-lw.entries:
+			if !ok {
+				lw.flush()
+				lw.closeFile()
+				close(lw.done)
+				return
+			}
+			lw.queueDepth.Add(-1)
+			lw.write(entry)
+		case <-ticker.C:
+			lw.flush()
+		}
+	}
+}
+
+// shutdown stops accepting the producer side and blocks until every
+// buffered entry has been written and fsynced.
+func (lw *logWriter) shutdown() {
+	lw.closeOnce.Do(func() {
+		close(lw.entries)
+	})
+	<-lw.done
+}
+
+func (lw *logWriter) write(entry LogEntry) {
+	if err := lw.rotateIfNeeded(time.Now().In(lw.location)); err != nil {
+		log.Printf("Failed to rotate log file: %v", err)
+		return
+	}
+
+	lw.logger.Info("request",
+		slog.String("timestamp", entry.Timestamp),
+		slog.String("method", entry.Method),
+		slog.String("path", entry.Path),
+		slog.Int("status", entry.Status),
+		slog.Int64("bytes", entry.Bytes),
+		slog.Float64("duration_ms", entry.Duration),
+	)
+
+	lw.pending++
+	if lw.pending >= logFlushBatchSize() {
+		lw.flush()
+	}
+}
+
+// rotateIfNeeded opens a fresh file (and slog handler pointed at it)
+// whenever the hour bucket changes. Bucket filenames already encode the
+// hour, so rolling over is just closing the old handle and opening the
+// new one - there's nothing to rename.
+func (lw *logWriter) rotateIfNeeded(now time.Time) error {
+	bucket := now.Format("2006-01-02T15")
+	if bucket == lw.bucket && lw.file != nil {
+		return nil
+	}
+
+	lw.closeFile()
+
+	path := filepath.Join(lw.logDir, bucket+".log")
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	// Drop slog's default time/level/msg attrs - downstream tooling
+	// expects the flat LogEntry shape that was already on disk.
+	handler := slog.NewJSONHandler(f, &slog.HandlerOptions{ReplaceAttr: dropDefaultSlogAttrs})
+
+	lw.file = f
+	lw.bucket = bucket
+	lw.logger = slog.New(handler)
+	return nil
+}
+
+func dropDefaultSlogAttrs(groups []string, a slog.Attr) slog.Attr {
+	switch a.Key {
+	case slog.TimeKey, slog.LevelKey, slog.MessageKey:
+		return slog.Attr{}
+	}
+	return a
+}
+
+func (lw *logWriter) flush() {
+	if lw.file == nil {
+		return
+	}
+	if err := lw.file.Sync(); err != nil {
+		log.Printf("Failed to fsync log file: %v", err)
+	}
+	lw.pending = 0
+}
+
+func (lw *logWriter) closeFile() {
+	if lw.file == nil {
+		return
+	}
+	if err := lw.file.Close(); err != nil {
+		log.Printf("Failed to close log file: %v", err)
+	}
+	lw.file = nil
+}
+
+// droppedCount and queuedCount back the internal admin endpoint.
+func (lw *logWriter) droppedCount() int64 {
+	return lw.droppedTotal.Load()
+}
+
+func (lw *logWriter) queuedCount() int64 {
+	return lw.queueDepth.Load()
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLogWriterRotateIfNeeded(t *testing.T) {
+	dir := t.TempDir()
+	lw := newLogWriter(dir, time.UTC)
+	defer lw.closeFile()
+
+	t1 := time.Date(2026, 7, 29, 14, 30, 0, 0, time.UTC)
+	if err := lw.rotateIfNeeded(t1); err != nil {
+		t.Fatalf("rotateIfNeeded() error = %v", err)
+	}
+	if want := "2026-07-29T14"; lw.bucket != want {
+		t.Errorf("bucket = %q, want %q", lw.bucket, want)
+	}
+	firstFile := lw.file
+
+	// Same hour bucket: no rotation, same file handle kept open.
+	t2 := t1.Add(10 * time.Minute)
+	if err := lw.rotateIfNeeded(t2); err != nil {
+		t.Fatalf("rotateIfNeeded() error = %v", err)
+	}
+	if lw.file != firstFile {
+		t.Errorf("file handle changed within the same hour bucket")
+	}
+
+	// New hour bucket: rotates to a new file.
+	t3 := t1.Add(time.Hour)
+	if err := lw.rotateIfNeeded(t3); err != nil {
+		t.Fatalf("rotateIfNeeded() error = %v", err)
+	}
+	if want := "2026-07-29T15"; lw.bucket != want {
+		t.Errorf("bucket = %q, want %q", lw.bucket, want)
+	}
+	if lw.file == firstFile {
+		t.Errorf("expected a new file handle after crossing an hour boundary")
+	}
+
+	for _, bucket := range []string{"2026-07-29T14", "2026-07-29T15"} {
+		if _, err := os.Stat(filepath.Join(dir, bucket+".log")); err != nil {
+			t.Errorf("expected log file for bucket %s: %v", bucket, err)
+		}
+	}
+}
+
+func TestLogWriterWriteFlushesAtBatchSize(t *testing.T) {
+	t.Setenv("LOG_FLUSH_BATCH_SIZE", "3")
+
+	dir := t.TempDir()
+	lw := newLogWriter(dir, time.UTC)
+	defer lw.closeFile()
+
+	entry := LogEntry{Timestamp: "2026-07-29T14:00:00Z", Method: "GET", Path: "/x", Status: 200}
+
+	lw.write(entry)
+	lw.write(entry)
+	if lw.pending != 2 {
+		t.Fatalf("pending = %d, want 2 before reaching the batch size", lw.pending)
+	}
+
+	lw.write(entry)
+	if lw.pending != 0 {
+		t.Errorf("pending = %d, want 0 after reaching the batch size (should have flushed)", lw.pending)
+	}
+}
+
+func TestLogWriterSubmitDropsWhenChannelFull(t *testing.T) {
+	lw := &logWriter{entries: make(chan LogEntry, 1)}
+
+	lw.submit(LogEntry{})
+	if got := lw.droppedCount(); got != 0 {
+		t.Fatalf("droppedCount() = %d, want 0 after the first submit", got)
+	}
+	if got := lw.queuedCount(); got != 1 {
+		t.Fatalf("queuedCount() = %d, want 1", got)
+	}
+
+	lw.submit(LogEntry{}) // channel is now full, this one is dropped
+	if got := lw.droppedCount(); got != 1 {
+		t.Errorf("droppedCount() = %d, want 1 after the channel fills up", got)
+	}
+	if got := lw.queuedCount(); got != 1 {
+		t.Errorf("queuedCount() = %d, want 1 (the dropped entry never entered the queue)", got)
+	}
+}
+
+func TestLogWriterShutdownDrainsPendingEntries(t *testing.T) {
+	dir := t.TempDir()
+	lw := newLogWriter(dir, time.UTC)
+	go lw.run()
+
+	const n = 5
+	for i := 0; i < n; i++ {
+		lw.submit(LogEntry{Timestamp: "2026-07-29T14:00:00Z", Method: "GET", Path: "/x", Status: 200})
+	}
+
+	lw.shutdown()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected exactly one log file, got %d", len(entries))
+	}
+
+	f, err := os.Open(filepath.Join(dir, entries[0].Name()))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer f.Close()
+
+	lines := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines++
+	}
+	if lines != n {
+		t.Errorf("got %d log lines, want %d", lines, n)
+	}
+}
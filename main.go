@@ -1,15 +1,21 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
 	"log"
+	"mime"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
+
+	"golang.org/x/crypto/acme/autocert"
+
+	"github.com/jpjerkins/simple-web-host/gopher"
+	"github.com/jpjerkins/simple-web-host/metrics"
 )
 
 // Allowed file extensions whitelist
@@ -69,11 +75,16 @@ func (rr *responseRecorder) Write(p []byte) (int, error) {
 }
 
 type Server struct {
-	wwwRoot        string
 	logDir         string
 	logger         *log.Logger
 	location       *time.Location
 	retentionHours int
+
+	sitesMu sync.RWMutex
+	sites   map[string]*Site
+
+	adminServer  *http.Server
+	gopherServer *gopher.Server
 }
 
 func main() {
@@ -91,10 +102,10 @@ func main() {
 	}
 
 	server := &Server{
-		wwwRoot:        wwwRoot,
 		logDir:         logDir,
 		location:       loc,
 		retentionHours: retentionHours,
+		sites:          make(map[string]*Site),
 	}
 
 	// Ensure log directory exists
@@ -102,12 +113,26 @@ func main() {
 		log.Fatalf("Failed to create log directory: %v", err)
 	}
 
+	// Load virtual hosts from SITES_CONFIG, or fall back to a single
+	// default site serving WWW_ROOT - this is what keeps single-site
+	// deployments working unchanged.
+	if sitesConfig := getEnv("SITES_CONFIG", ""); sitesConfig != "" {
+		if err := server.loadSites(sitesConfig); err != nil {
+			log.Fatalf("Failed to load sites config: %v", err)
+		}
+	} else {
+		server.RegisterSite("", wwwRoot)
+	}
+
 	// Clean up old logs on startup
 	server.cleanupOldLogs()
 
 	// Start cleanup goroutine
 	go server.periodicCleanup()
 
+	server.startAdminListener()
+	server.startGopherListener()
+
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", server.handleRequest)
 
@@ -117,19 +142,70 @@ func main() {
 	log.Printf("Log directory: %s", logDir)
 	log.Printf("Timezone: %s", loc.String())
 
-	if err := http.ListenAndServe(addr, mux); err != nil {
-		log.Fatalf("Server failed: %v", err)
+	tlsCfg := loadTLSConfig()
+
+	var plainHandler http.Handler = mux
+	if tlsCfg.enabled() && tlsCfg.redirectHTTP {
+		plainHandler = redirectToHTTPS(tlsCfg.addr)
+	}
+
+	httpServer := &http.Server{
+		Addr:              addr,
+		Handler:           plainHandler,
+		ReadHeaderTimeout: serverReadHeaderTimeout,
+		IdleTimeout:       serverIdleTimeout,
+		WriteTimeout:      serverWriteTimeout,
+	}
+
+	var httpsServer *http.Server
+	if tlsCfg.enabled() {
+		var mgr *autocert.Manager
+		httpsServer, mgr = newHTTPSServer(mux, tlsCfg, logDir)
+		if mgr != nil {
+			// ACME HTTP-01 challenges must be served from the plain
+			// listener, so layer the challenge handler in front of
+			// whatever :8080 is already doing.
+			httpServer.Handler = mgr.HTTPHandler(plainHandler)
+		}
+
+		log.Printf("Starting TLS listener on %s", httpsServer.Addr)
+		go func() {
+			var err error
+			if mgr != nil {
+				err = httpsServer.ListenAndServeTLS("", "")
+			} else {
+				err = httpsServer.ListenAndServeTLS(tlsCfg.certFile, tlsCfg.keyFile)
+			}
+			if err != nil && err != http.ErrServerClosed {
+				log.Fatalf("TLS server failed: %v", err)
+			}
+		}()
 	}
+
+	go func() {
+		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Server failed: %v", err)
+		}
+	}()
+
+	waitForShutdown(httpServer, httpsServer, server)
 }
 
 func (s *Server) handleRequest(w http.ResponseWriter, r *http.Request) {
 	start := time.Now()
 	path := r.URL.Path
-	
+
 	// Wrap response writer to capture status
 	wrapped := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
 
-	// Log the request at the end
+	site := s.resolveSite(r.Host)
+	if site == nil {
+		http.Error(wrapped, "Not Found", http.StatusNotFound)
+		return
+	}
+
+	// Log the request at the end, against the resolved site's own log
+	// stream, and fold it into the metrics registry.
 	defer func() {
 		duration := time.Since(start).Seconds() * 1000
 		entry := LogEntry{
@@ -140,7 +216,13 @@ func (s *Server) handleRequest(w http.ResponseWriter, r *http.Request) {
 			Bytes:     wrapped.bytesWritten,
 			Duration:  duration,
 		}
-		s.logRequest(entry)
+		site.logWriter.submit(entry)
+		metrics.Observe(metrics.Hit{
+			Method:   metricsMethod(r.Method),
+			Ext:      metricsExt(site, path),
+			Status:   wrapped.statusCode,
+			Duration: duration,
+		})
 	}()
 
 	// Only allow GET and HEAD
@@ -149,45 +231,91 @@ func (s *Server) handleRequest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Sanitize and validate path
-	cleanPath, err := s.sanitizePath(path)
-	if err != nil {
+	// Reject traversal/subdirectory attempts; the file map is the
+	// canonical source of truth for what's actually servable.
+	if _, err := site.sanitizePath(path); err != nil {
 		http.Error(wrapped, "Forbidden", http.StatusForbidden)
 		return
 	}
 
-	// Check if file exists and is not a directory
-	info, err := os.Stat(cleanPath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			http.Error(wrapped, "Not Found", http.StatusNotFound)
-		} else {
-			http.Error(wrapped, "Internal Server Error", http.StatusInternalServerError)
-		}
+	entry, ok := site.fileMap.lookup(path)
+	if !ok {
+		http.Error(wrapped, "Not Found", http.StatusNotFound)
 		return
 	}
 
-	if info.IsDir() {
-		http.Error(wrapped, "Forbidden", http.StatusForbidden)
-		return
-	}
+	wrapped.Header().Set("ETag", entry.etag)
+	wrapped.Header().Set("Last-Modified", entry.mtime.UTC().Format(http.TimeFormat))
 
-	// Validate extension
-	ext := strings.ToLower(filepath.Ext(cleanPath))
-	if blockedExts[ext] {
-		http.Error(wrapped, "Forbidden", http.StatusForbidden)
+	if notModified(r, entry) {
+		wrapped.WriteHeader(http.StatusNotModified)
 		return
 	}
-	if !allowedExts[ext] {
-		http.Error(wrapped, "Forbidden", http.StatusForbidden)
-		return
+
+	// Prefer a precompressed sibling over compressing on the fly, and
+	// never compress range requests (the range would no longer line up
+	// with the original file's bytes).
+	if r.Header.Get("Range") == "" && compressible(entry.path) {
+		if variantPath, encoding, ok := precompressed(r, entry.path); ok {
+			// ServeContent derives Content-Type and Last-Modified from
+			// the served file's own name/mtime when called via
+			// ServeFile, which here would be the compressed sibling's -
+			// not the original's. Open it ourselves and pass entry's
+			// extension and mtime explicitly so both headers describe
+			// the original, decompressed file.
+			if ctype := mime.TypeByExtension(filepath.Ext(entry.path)); ctype != "" {
+				wrapped.Header().Set("Content-Type", ctype)
+			}
+			wrapped.Header().Set("Content-Encoding", encoding)
+			wrapped.Header().Add("Vary", "Accept-Encoding")
+
+			f, err := os.Open(variantPath)
+			if err != nil {
+				http.Error(wrapped, "Internal Server Error", http.StatusInternalServerError)
+				return
+			}
+			defer f.Close()
+			http.ServeContent(wrapped, r, "", entry.mtime, f)
+			return
+		}
+
+		if entry.size >= minCompressSize() && acceptsEncoding(r, "gzip") {
+			gz := newGzipResponseWriter(wrapped, gzipLevel())
+			defer gz.Close()
+			http.ServeFile(gz, r, entry.path)
+			return
+		}
 	}
 
 	// Serve the file
-	http.ServeFile(wrapped, r, cleanPath)
+	http.ServeFile(wrapped, r, entry.path)
+}
+
+// metricsExt returns the extension to record on the metrics registry,
+// folding anything outside the site's own whitelist into "other" so a
+// client can't grow the registry's cardinality without bound by probing
+// arbitrary extensions.
+func metricsExt(site *Site, path string) string {
+	ext := strings.ToLower(filepath.Ext(path))
+	if site.allowedExts[ext] {
+		return ext
+	}
+	return "other"
+}
+
+// metricsMethod folds anything outside the two methods this server
+// actually serves into "other", for the same cardinality-bounding reason
+// as metricsExt: the deferred logger is installed before the GET/HEAD
+// check runs, so rejected verbs are metriced too, and an attacker sending arbitrary verbs must not
+// be able to grow the registry with a new series per verb.
+func metricsMethod(method string) string {
+	if method == http.MethodGet || method == http.MethodHead {
+		return method
+	}
+	return "other"
 }
 
-func (s *Server) sanitizePath(p string) (string, error) {
+func (site *Site) sanitizePath(p string) (string, error) {
 	// Remove leading slash
 	p = strings.TrimPrefix(p, "/")
 
@@ -214,52 +342,45 @@ func (s *Server) sanitizePath(p string) (string, error) {
 		return "", fmt.Errorf("directory traversal detected after clean")
 	}
 
-	// Join with www root
-	fullPath := filepath.Join(s.wwwRoot, clean)
+	// Join with the site's root
+	fullPath := filepath.Join(site.root, clean)
 
-	// Verify the path is still within www root (final safety check)
-	absWwwRoot, err := filepath.Abs(s.wwwRoot)
+	// Verify the path is still within the site's root (final safety check)
+	absRoot, err := filepath.Abs(site.root)
 	if err != nil {
-		return "", fmt.Errorf("failed to get absolute www root: %w", err)
+		return "", fmt.Errorf("failed to get absolute site root: %w", err)
 	}
 	absPath, err := filepath.Abs(fullPath)
 	if err != nil {
 		return "", fmt.Errorf("failed to get absolute path: %w", err)
 	}
 
-	if !strings.HasPrefix(absPath, absWwwRoot+string(filepath.Separator)) && absPath != absWwwRoot {
-		return "", fmt.Errorf("path escapes www root")
+	if !strings.HasPrefix(absPath, absRoot+string(filepath.Separator)) && absPath != absRoot {
+		return "", fmt.Errorf("path escapes site root")
 	}
 
 	return fullPath, nil
 }
 
-func (s *Server) logRequest(entry LogEntry) {
-	logFile := s.currentLogFile()
-	
-	f, err := os.OpenFile(logFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		log.Printf("Failed to open log file %s: %v", logFile, err)
-		return
+// cleanupOldLogs sweeps every registered site's log directory, since
+// each site writes its JSONL stream to its own directory.
+func (s *Server) cleanupOldLogs() {
+	s.sitesMu.RLock()
+	dirs := make([]string, 0, len(s.sites))
+	for _, site := range s.sites {
+		dirs = append(dirs, siteLogDir(s.logDir, site.host))
 	}
-	defer f.Close()
+	s.sitesMu.RUnlock()
 
-	encoder := json.NewEncoder(f)
-	if err := encoder.Encode(entry); err != nil {
-		log.Printf("Failed to encode log entry: %v", err)
+	for _, dir := range dirs {
+		s.cleanupLogDir(dir)
 	}
 }
 
-func (s *Server) currentLogFile() string {
-	now := time.Now().In(s.location)
-	filename := now.Format("2006-01-02T15") + ".log"
-	return filepath.Join(s.logDir, filename)
-}
-
-func (s *Server) cleanupOldLogs() {
-	entries, err := os.ReadDir(s.logDir)
+func (s *Server) cleanupLogDir(dir string) {
+	entries, err := os.ReadDir(dir)
 	if err != nil {
-		log.Printf("Failed to read log directory for cleanup: %v", err)
+		log.Printf("Failed to read log directory %s for cleanup: %v", dir, err)
 		return
 	}
 
@@ -282,7 +403,7 @@ func (s *Server) cleanupOldLogs() {
 		}
 
 		if t.Before(cutoff) {
-			path := filepath.Join(s.logDir, name)
+			path := filepath.Join(dir, name)
 			if err := os.Remove(path); err != nil {
 				log.Printf("Failed to remove old log %s: %v", path, err)
 			} else {
@@ -0,0 +1,67 @@
+package main
+
+import "testing"
+
+func TestSanitizePath(t *testing.T) {
+	site := &Site{root: "/var/www", allowedExts: allowedExts, blockedExts: blockedExts}
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{name: "ordinary file", path: "/index.html", wantErr: false},
+		{name: "directory root", path: "/", wantErr: true},
+		{name: "subdirectory", path: "/sub/file.html", wantErr: true},
+		{name: "backslash", path: "/sub\\file.html", wantErr: true},
+		{name: "traversal", path: "/../etc/passwd", wantErr: true},
+		{name: "encoded traversal survives clean", path: "/..%2f..%2fetc%2fpasswd", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := site.sanitizePath(tc.path)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("sanitizePath(%q) error = %v, wantErr %v", tc.path, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestMetricsExt(t *testing.T) {
+	site := &Site{allowedExts: map[string]bool{".html": true, ".css": true}}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/index.html", want: ".html"},
+		{path: "/style.css", want: ".css"},
+		{path: "/app.exe", want: "other"},
+		{path: "/noext", want: "other"},
+	}
+
+	for _, tc := range tests {
+		if got := metricsExt(site, tc.path); got != tc.want {
+			t.Errorf("metricsExt(%q) = %q, want %q", tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestMetricsMethod(t *testing.T) {
+	tests := []struct {
+		method string
+		want   string
+	}{
+		{method: "GET", want: "GET"},
+		{method: "HEAD", want: "HEAD"},
+		{method: "POST", want: "other"},
+		{method: "FOOBAR", want: "other"},
+	}
+
+	for _, tc := range tests {
+		if got := metricsMethod(tc.method); got != tc.want {
+			t.Errorf("metricsMethod(%q) = %q, want %q", tc.method, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+// Package metrics is a minimal Prometheus-style counter/histogram/gauge
+// registry that renders the standard text exposition format directly,
+// with no external client library.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Hit is one observed request, used to update every series keyed by
+// status/method/ext.
+type Hit struct {
+	Method   string
+	Ext      string
+	Status   int
+	Duration float64 // milliseconds
+}
+
+// durationBucketsMS are the histogram bucket upper bounds, in
+// milliseconds, for request_duration_ms.
+var durationBucketsMS = []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000}
+
+var (
+	mu          sync.Mutex
+	counters    = map[string]int64{}
+	histCounts  = map[string]int64{}
+	histSums    = map[string]float64{}
+	histBuckets = map[string][]int64{}
+)
+
+// Observe records one request against requests_total and the
+// request_duration_ms histogram.
+func Observe(hit Hit) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	key := seriesKey(hit.Status, hit.Method, hit.Ext)
+	counters[key]++
+	histCounts[key]++
+	histSums[key] += hit.Duration
+
+	buckets, ok := histBuckets[key]
+	if !ok {
+		buckets = make([]int64, len(durationBucketsMS))
+		histBuckets[key] = buckets
+	}
+	for i, upper := range durationBucketsMS {
+		if hit.Duration <= upper {
+			buckets[i]++
+		}
+	}
+}
+
+func seriesKey(status int, method, ext string) string {
+	return strconv.Itoa(status) + "|" + method + "|" + ext
+}
+
+func splitKey(key string) (status, method, ext string) {
+	parts := strings.SplitN(key, "|", 3)
+	return parts[0], parts[1], parts[2]
+}
+
+// GaugeFunc computes a gauge's current value on scrape, for values like
+// queue depth or open file descriptors that aren't meaningfully
+// incremented.
+type GaugeFunc func() float64
+
+var (
+	gaugesMu sync.Mutex
+	gauges   = map[string]GaugeFunc{}
+)
+
+// RegisterGauge adds (or replaces) a gauge series under name.
+func RegisterGauge(name string, fn GaugeFunc) {
+	gaugesMu.Lock()
+	defer gaugesMu.Unlock()
+	gauges[name] = fn
+}
+
+// WriteText renders every registered series in Prometheus text
+// exposition format.
+func WriteText(w io.Writer) {
+	mu.Lock()
+	keys := make([]string, 0, len(counters))
+	for k := range counters {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fmt.Fprintln(w, "# TYPE requests_total counter")
+	for _, k := range keys {
+		status, method, ext := splitKey(k)
+		fmt.Fprintf(w, "requests_total{status=%q,method=%q,ext=%q} %d\n", status, method, ext, counters[k])
+	}
+
+	fmt.Fprintln(w, "# TYPE request_duration_ms histogram")
+	for _, k := range keys {
+		status, method, ext := splitKey(k)
+		buckets := histBuckets[k]
+		for i, upper := range durationBucketsMS {
+			le := strconv.FormatFloat(upper, 'f', -1, 64)
+			fmt.Fprintf(w, "request_duration_ms_bucket{status=%q,method=%q,ext=%q,le=%q} %d\n", status, method, ext, le, buckets[i])
+		}
+		fmt.Fprintf(w, "request_duration_ms_bucket{status=%q,method=%q,ext=%q,le=\"+Inf\"} %d\n", status, method, ext, histCounts[k])
+		fmt.Fprintf(w, "request_duration_ms_sum{status=%q,method=%q,ext=%q} %s\n", status, method, ext, strconv.FormatFloat(histSums[k], 'f', -1, 64))
+		fmt.Fprintf(w, "request_duration_ms_count{status=%q,method=%q,ext=%q} %d\n", status, method, ext, histCounts[k])
+	}
+	mu.Unlock()
+
+	gaugesMu.Lock()
+	names := make([]string, 0, len(gauges))
+	for name := range gauges {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "# TYPE %s gauge\n%s %s\n", name, name, strconv.FormatFloat(gauges[name](), 'f', -1, 64))
+	}
+	gaugesMu.Unlock()
+}
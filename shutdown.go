@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+const shutdownTimeout = 10 * time.Second
+
+// waitForShutdown blocks until SIGINT/SIGTERM, then stops accepting new
+// connections on every listener (httpsServer, the admin listener, and
+// the gopher listener may all be nil when their feature isn't enabled)
+// and only then drains every site's log writer, so no listener's
+// goroutines can still be submitting to a log writer after its channel
+// is closed. Finally it flushes and fsyncs every buffered log entry
+// before the process exits.
+func waitForShutdown(httpServer, httpsServer *http.Server, srv *Server) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	<-sigCh
+
+	log.Printf("Shutdown signal received, draining...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(ctx); err != nil {
+		log.Printf("HTTP server shutdown error: %v", err)
+	}
+	if httpsServer != nil {
+		if err := httpsServer.Shutdown(ctx); err != nil {
+			log.Printf("HTTPS server shutdown error: %v", err)
+		}
+	}
+	if srv.adminServer != nil {
+		if err := srv.adminServer.Shutdown(ctx); err != nil {
+			log.Printf("Admin listener shutdown error: %v", err)
+		}
+	}
+	if srv.gopherServer != nil {
+		if err := srv.gopherServer.Shutdown(ctx); err != nil {
+			log.Printf("Gopher listener shutdown error: %v", err)
+		}
+	}
+
+	srv.sitesMu.RLock()
+	writers := make([]*logWriter, 0, len(srv.sites))
+	for _, site := range srv.sites {
+		writers = append(writers, site.logWriter)
+	}
+	srv.sitesMu.RUnlock()
+
+	for _, lw := range writers {
+		lw.shutdown()
+	}
+	log.Printf("Shutdown complete")
+}
@@ -0,0 +1,59 @@
+package main
+
+import "testing"
+
+func TestStripPort(t *testing.T) {
+	tests := []struct {
+		name string
+		host string
+		want string
+	}{
+		{name: "bare host", host: "Example.com", want: "example.com"},
+		{name: "host with port", host: "Example.com:8080", want: "example.com"},
+		{name: "ipv6 with port", host: "[::1]:8080", want: "::1"},
+		{name: "empty", host: "", want: ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := stripPort(tc.host); got != tc.want {
+				t.Errorf("stripPort(%q) = %q, want %q", tc.host, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestResolveSite(t *testing.T) {
+	def := &Site{host: ""}
+	named := &Site{host: "example.com"}
+
+	s := &Server{sites: map[string]*Site{
+		"":            def,
+		"example.com": named,
+	}}
+
+	tests := []struct {
+		name string
+		host string
+		want *Site
+	}{
+		{name: "exact match", host: "example.com:443", want: named},
+		{name: "case-insensitive match", host: "Example.Com", want: named},
+		{name: "unknown host falls back to default", host: "other.com", want: def},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := s.resolveSite(tc.host); got != tc.want {
+				t.Errorf("resolveSite(%q) = %v, want %v", tc.host, got, tc.want)
+			}
+		})
+	}
+
+	t.Run("no default registered returns nil", func(t *testing.T) {
+		s := &Server{sites: map[string]*Site{"example.com": named}}
+		if got := s.resolveSite("other.com"); got != nil {
+			t.Errorf("resolveSite() = %v, want nil", got)
+		}
+	})
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// sitesFileConfig is the on-disk shape of SITES_CONFIG: a flat list of
+// virtual hosts, each with its own root and optional extension
+// overrides.
+type sitesFileConfig struct {
+	Sites []siteFileConfig `json:"sites" yaml:"sites"`
+}
+
+type siteFileConfig struct {
+	Host        string   `json:"host" yaml:"host"`
+	Root        string   `json:"root" yaml:"root"`
+	AllowedExts []string `json:"allowedExts,omitempty" yaml:"allowedExts,omitempty"`
+	BlockedExts []string `json:"blockedExts,omitempty" yaml:"blockedExts,omitempty"`
+}
+
+// loadSites reads the YAML or JSON file at configPath (format picked by
+// its extension, defaulting to JSON) and registers every site it
+// describes. Host should be the lowercase hostname clients will send,
+// without a port.
+func (s *Server) loadSites(configPath string) error {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read sites config: %w", err)
+	}
+
+	var cfg sitesFileConfig
+	if ext := strings.ToLower(filepath.Ext(configPath)); ext == ".yaml" || ext == ".yml" {
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			return fmt.Errorf("failed to parse sites config: %w", err)
+		}
+	} else {
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return fmt.Errorf("failed to parse sites config: %w", err)
+		}
+	}
+
+	if len(cfg.Sites) == 0 {
+		return fmt.Errorf("sites config %s defines no sites", configPath)
+	}
+
+	for _, sc := range cfg.Sites {
+		if sc.Host == "" || sc.Root == "" {
+			return fmt.Errorf("sites config: host and root are required (got host=%q root=%q)", sc.Host, sc.Root)
+		}
+
+		var opts []SiteOption
+		if len(sc.AllowedExts) > 0 {
+			opts = append(opts, WithAllowedExts(sc.AllowedExts...))
+		}
+		if len(sc.BlockedExts) > 0 {
+			opts = append(opts, WithBlockedExts(sc.BlockedExts...))
+		}
+
+		s.RegisterSite(stripPort(sc.Host), sc.Root, opts...)
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+const (
+	defaultTLSAddr          = ":8443"
+	serverReadHeaderTimeout = 5 * time.Second
+	serverIdleTimeout       = 60 * time.Second
+	serverWriteTimeout      = 30 * time.Second
+)
+
+// tlsConfig describes how (or whether) to serve HTTPS, derived entirely
+// from env vars so the binary keeps working with zero config for plain
+// HTTP deployments.
+type tlsConfig struct {
+	certFile        string
+	keyFile         string
+	autocertDomains []string
+	addr            string
+	redirectHTTP    bool
+}
+
+func loadTLSConfig() tlsConfig {
+	var domains []string
+	if raw := getEnv("AUTOCERT_DOMAINS", ""); raw != "" {
+		for _, d := range strings.Split(raw, ",") {
+			domains = append(domains, strings.TrimSpace(d))
+		}
+	}
+
+	return tlsConfig{
+		certFile:        getEnv("TLS_CERT", ""),
+		keyFile:         getEnv("TLS_KEY", ""),
+		autocertDomains: domains,
+		addr:            getEnv("TLS_ADDR", defaultTLSAddr),
+		redirectHTTP:    getEnv("REDIRECT_HTTP", "") != "",
+	}
+}
+
+func (c tlsConfig) enabled() bool {
+	return (c.certFile != "" && c.keyFile != "") || len(c.autocertDomains) > 0
+}
+
+// newHTTPSServer builds the HTTPS http.Server. When autocert domains are
+// configured it returns the certManager too, since the plain :8080
+// listener needs to serve its HTTP-01 challenge handler.
+func newHTTPSServer(handler http.Handler, cfg tlsConfig, logDir string) (*http.Server, *autocert.Manager) {
+	srv := &http.Server{
+		Addr:              cfg.addr,
+		Handler:           handler,
+		ReadHeaderTimeout: serverReadHeaderTimeout,
+		IdleTimeout:       serverIdleTimeout,
+		WriteTimeout:      serverWriteTimeout,
+	}
+
+	if len(cfg.autocertDomains) == 0 {
+		return srv, nil
+	}
+
+	certCache := filepath.Join(logDir, "..", "certs")
+	if err := os.MkdirAll(certCache, 0700); err != nil {
+		log.Fatalf("Failed to create autocert cache dir: %v", err)
+	}
+
+	mgr := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(cfg.autocertDomains...),
+		Cache:      autocert.DirCache(certCache),
+	}
+	srv.TLSConfig = mgr.TLSConfig()
+	return srv, mgr
+}
+
+// redirectToHTTPS turns the plain listener into a permanent redirect to
+// the HTTPS URL for the same host and path, used in place of the normal
+// mux when REDIRECT_HTTP is set.
+func redirectToHTTPS(httpsAddr string) http.HandlerFunc {
+	_, port, _ := net.SplitHostPort(httpsAddr)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+
+		target := "https://" + host
+		if port != "" && port != "443" {
+			target += ":" + port
+		}
+		target += r.URL.RequestURI()
+
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+	}
+}